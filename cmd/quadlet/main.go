@@ -0,0 +1,116 @@
+// Command quadlet is a systemd generator that turns Quadlet unit files
+// (.container, .volume, .kube, .pod, .network, .build) dropped into
+// /etc/containers/systemd/ into the systemd service units that run
+// them. systemd invokes generators with the directories it expects
+// generated units to be written to; see systemd.generator(7).
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/containers/podman/v4/pkg/systemd/quadlet"
+)
+
+// defaultQuadletDirs are searched, in order, for source Quadlet files.
+// Later directories take precedence, matching systemd's own unit file
+// search order (administrator overrides in /etc win over vendor files
+// in /usr/lib). QUADLET_UNIT_DIRS overrides this list entirely, which
+// integration tests use to point the generator at a scratch directory
+// instead of the real system paths.
+var defaultQuadletDirs = []string{
+	"/usr/share/containers/systemd",
+	"/etc/containers/systemd",
+}
+
+func quadletDirs() []string {
+	if env := os.Getenv("QUADLET_UNIT_DIRS"); env != "" {
+		return strings.Split(env, ":")
+	}
+	return defaultQuadletDirs
+}
+
+func main() {
+	args := os.Args[1:]
+
+	var dryRun, jsonFormat bool
+	var positional []string
+	for _, arg := range args {
+		switch arg {
+		case "-dryrun", "--dryrun":
+			dryRun = true
+		case "-format=json", "--format=json":
+			jsonFormat = true
+		case "-no-kmsg-log", "--no-kmsg-log":
+			// Accepted for compatibility with systemd's generator
+			// invocation but doesn't change behavior here; logging
+			// destination is handled by systemd itself.
+		default:
+			positional = append(positional, arg)
+		}
+	}
+
+	if dryRun {
+		runDryRun(jsonFormat)
+		return
+	}
+
+	if len(positional) < 1 {
+		fmt.Fprintln(os.Stderr, "requires a generator output directory as argument")
+		os.Exit(1)
+	}
+	outputDir := positional[0]
+
+	var hadError bool
+	for _, dir := range quadletDirs() {
+		if _, err := os.Stat(dir); err != nil {
+			continue
+		}
+		for _, err := range quadlet.GenerateUnits(dir, outputDir) {
+			fmt.Fprintln(os.Stderr, "error:", err)
+			hadError = true
+		}
+	}
+
+	if hadError {
+		os.Exit(1)
+	}
+}
+
+// runDryRun implements `quadlet -dryrun -format=json`: instead of
+// writing generated units to disk, it prints a JSON description of
+// every unit it would have generated so Quadlet files can be validated
+// in CI without installing them.
+func runDryRun(jsonFormat bool) {
+	if !jsonFormat {
+		fmt.Fprintln(os.Stderr, "-dryrun currently requires -format=json")
+		os.Exit(1)
+	}
+
+	result := &quadlet.DryRunResult{}
+	hadError := false
+	for _, dir := range quadletDirs() {
+		if _, err := os.Stat(dir); err != nil {
+			continue
+		}
+		dirResult, err := quadlet.DryRun(dir)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "error:", err)
+			hadError = true
+			continue
+		}
+		result.Units = append(result.Units, dirResult.Units...)
+	}
+
+	out, err := result.ToJSON()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "error:", err)
+		os.Exit(1)
+	}
+	fmt.Println(string(out))
+
+	if hadError {
+		os.Exit(1)
+	}
+}
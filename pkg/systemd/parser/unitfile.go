@@ -0,0 +1,337 @@
+// Package parser implements a small, forgiving reader/writer for the
+// systemd unit file format (the same key=value-in-groups syntax used by
+// .service, .container, .volume, .kube, .pod, .network and .build
+// Quadlet files). It intentionally only supports the subset of the
+// format that Quadlet needs: group headers, key=value pairs, line
+// continuation with a trailing backslash, "#"/";" comments and repeated
+// keys.
+package parser
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/mattn/go-shellwords"
+)
+
+// UnitEntry is a single key=value line that appeared in a group.
+type UnitEntry struct {
+	Key   string
+	Value string
+}
+
+// UnitGroup is an ordered list of entries that appeared in a named
+// group (e.g. "[Container]").
+type UnitGroup struct {
+	Name    string
+	Entries []UnitEntry
+}
+
+// UnitFile is the in-memory representation of a parsed unit file. It
+// preserves the order in which groups and keys were encountered so that
+// ToString round-trips as closely as possible to the input.
+type UnitFile struct {
+	Path   string
+	groups []*UnitGroup
+}
+
+// NewUnitFile creates an empty, unpathed UnitFile that callers can
+// populate with Add/Set before calling ToString.
+func NewUnitFile() *UnitFile {
+	return &UnitFile{}
+}
+
+// Filename returns the base name of the file this unit was read from or
+// will be written to, e.g. "foo.container".
+func (f *UnitFile) Filename() string {
+	return filepath.Base(f.Path)
+}
+
+func (f *UnitFile) group(name string) *UnitGroup {
+	for _, g := range f.groups {
+		if g.Name == name {
+			return g
+		}
+	}
+	return nil
+}
+
+func (f *UnitFile) ensureGroup(name string) *UnitGroup {
+	if g := f.group(name); g != nil {
+		return g
+	}
+	g := &UnitGroup{Name: name}
+	f.groups = append(f.groups, g)
+	return g
+}
+
+// HasGroup reports whether the group appears anywhere in the file.
+func (f *UnitFile) HasGroup(group string) bool {
+	return f.group(group) != nil
+}
+
+// ListGroups returns the names of all groups, in file order.
+func (f *UnitFile) ListGroups() []string {
+	names := make([]string, 0, len(f.groups))
+	for _, g := range f.groups {
+		names = append(names, g.Name)
+	}
+	return names
+}
+
+// ListKeys returns every key set in the given group, in file order,
+// with duplicates for keys that were repeated.
+func (f *UnitFile) ListKeys(group string) []string {
+	g := f.group(group)
+	if g == nil {
+		return nil
+	}
+	keys := make([]string, 0, len(g.Entries))
+	for _, e := range g.Entries {
+		keys = append(keys, e.Key)
+	}
+	return keys
+}
+
+// Add appends a new key=value entry to group, without touching any
+// existing entries for that key. Used for multi-value keys such as
+// Environment= or PublishPort=.
+func (f *UnitFile) Add(group, key, value string) {
+	g := f.ensureGroup(group)
+	g.Entries = append(g.Entries, UnitEntry{Key: key, Value: value})
+}
+
+// Set replaces all existing values for key in group with a single new
+// value.
+func (f *UnitFile) Set(group, key, value string) {
+	f.Remove(group, key)
+	f.Add(group, key, value)
+}
+
+// Remove deletes every entry for key in group and reports whether
+// anything was removed.
+func (f *UnitFile) Remove(group, key string) bool {
+	g := f.group(group)
+	if g == nil {
+		return false
+	}
+	removed := false
+	kept := g.Entries[:0]
+	for _, e := range g.Entries {
+		if e.Key == key {
+			removed = true
+			continue
+		}
+		kept = append(kept, e)
+	}
+	g.Entries = kept
+	return removed
+}
+
+// LookupLast returns the value of the last occurrence of key in group.
+func (f *UnitFile) LookupLast(group, key string) (string, bool) {
+	g := f.group(group)
+	if g == nil {
+		return "", false
+	}
+	found := false
+	var value string
+	for _, e := range g.Entries {
+		if e.Key == key {
+			value = e.Value
+			found = true
+		}
+	}
+	return value, found
+}
+
+// LookupAll returns the values of every occurrence of key in group, in
+// file order.
+func (f *UnitFile) LookupAll(group, key string) []string {
+	g := f.group(group)
+	if g == nil {
+		return nil
+	}
+	var values []string
+	for _, e := range g.Entries {
+		if e.Key == key {
+			values = append(values, e.Value)
+		}
+	}
+	return values
+}
+
+// LookupBoolean returns the last value of key interpreted as a systemd
+// boolean ("1"/"yes"/"true"/"on" are true), falling back to
+// defaultValue if the key is unset or unparsable.
+func (f *UnitFile) LookupBoolean(group, key string, defaultValue bool) bool {
+	value, ok := f.LookupLast(group, key)
+	if !ok {
+		return defaultValue
+	}
+	switch strings.ToLower(value) {
+	case "1", "yes", "true", "on":
+		return true
+	case "0", "no", "false", "off":
+		return false
+	default:
+		return defaultValue
+	}
+}
+
+// LookupInt returns the last value of key parsed as an integer, falling
+// back to defaultValue if the key is unset or unparsable.
+func (f *UnitFile) LookupInt(group, key string, defaultValue int64) int64 {
+	value, ok := f.LookupLast(group, key)
+	if !ok {
+		return defaultValue
+	}
+	i, err := strconv.ParseInt(strings.TrimSpace(value), 10, 64)
+	if err != nil {
+		return defaultValue
+	}
+	return i
+}
+
+// LookupLastArgs shell-splits the last value of key in group, the way
+// systemd splits Exec*= lines. It is used to both read and later
+// re-split podman command lines stored in ExecStart=/ExecStop=.
+func (f *UnitFile) LookupLastArgs(group, key string) ([]string, bool) {
+	value, ok := f.LookupLast(group, key)
+	if !ok {
+		return nil, false
+	}
+	args, err := shellwords.Parse(value)
+	if err != nil {
+		return nil, false
+	}
+	return args, true
+}
+
+// ToString serializes the unit file back into systemd unit syntax.
+func (f *UnitFile) ToString() (string, error) {
+	var b strings.Builder
+	for i, g := range f.groups {
+		if i > 0 {
+			b.WriteString("\n")
+		}
+		fmt.Fprintf(&b, "[%s]\n", escapeContinuations(g.Name))
+		for _, e := range g.Entries {
+			// A value (or key, or group name) built up from a
+			// "\"-continuation, or set programmatically with an
+			// embedded newline, has to be re-escaped the same way on
+			// the way back out, or the "\n" is written literally and
+			// the line after it is read back as a line of its own
+			// instead of being folded into this one.
+			fmt.Fprintf(&b, "%s=%s\n", escapeContinuations(e.Key), escapeContinuations(e.Value))
+		}
+	}
+	return b.String(), nil
+}
+
+// escapeContinuations re-encodes embedded newlines as a trailing
+// backslash followed by a real newline, i.e. the systemd line
+// continuation syntax ParseUnitFileString folds them from. A value that
+// itself ends in a backslash gets a trailing space, so that it is not
+// read back as a continuation; the space is trimmed again on parsing.
+func escapeContinuations(value string) string {
+	escaped := strings.ReplaceAll(value, "\n", "\\\n")
+	if strings.HasSuffix(escaped, "\\") {
+		escaped += " "
+	}
+	return escaped
+}
+
+// Clone returns a deep copy of the unit file, optionally keeping Path.
+func (f *UnitFile) Clone(copyPath bool) *UnitFile {
+	n := NewUnitFile()
+	if copyPath {
+		n.Path = f.Path
+	}
+	for _, g := range f.groups {
+		ng := &UnitGroup{Name: g.Name, Entries: append([]UnitEntry(nil), g.Entries...)}
+		n.groups = append(n.groups, ng)
+	}
+	return n
+}
+
+// ParseUnitFile reads and parses the unit file at path.
+func ParseUnitFile(path string) (*UnitFile, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	f, err := ParseUnitFileString(string(data))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	f.Path = path
+	return f, nil
+}
+
+// ParseUnitFileString parses unit file syntax from an in-memory string.
+func ParseUnitFileString(data string) (*UnitFile, error) {
+	f := NewUnitFile()
+
+	// Normalize CRLF and fold backslash-terminated continuation lines
+	// before splitting into logical lines, matching systemd's own
+	// unit-file line folding.
+	data = strings.ReplaceAll(data, "\r\n", "\n")
+
+	var logicalLines []string
+	var cur strings.Builder
+	for _, line := range strings.Split(data, "\n") {
+		if strings.HasSuffix(line, "\\") {
+			cur.WriteString(strings.TrimSuffix(line, "\\"))
+			cur.WriteString("\n")
+			continue
+		}
+		cur.WriteString(line)
+		logicalLines = append(logicalLines, cur.String())
+		cur.Reset()
+	}
+	if cur.Len() > 0 {
+		logicalLines = append(logicalLines, cur.String())
+	}
+
+	currentGroup := ""
+	for lineNo, line := range logicalLines {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" {
+			continue
+		}
+		if strings.HasPrefix(trimmed, "#") || strings.HasPrefix(trimmed, ";") {
+			continue
+		}
+		if strings.HasPrefix(trimmed, "[") {
+			end := strings.Index(trimmed, "]")
+			if end < 0 {
+				return nil, fmt.Errorf("invalid group header on line %d: %q", lineNo+1, line)
+			}
+			currentGroup = strings.TrimSpace(trimmed[1:end])
+			f.ensureGroup(currentGroup)
+			continue
+		}
+		if currentGroup == "" {
+			return nil, fmt.Errorf("key=value line %d outside of any group: %q", lineNo+1, line)
+		}
+		eq := strings.Index(line, "=")
+		if eq < 0 {
+			return nil, fmt.Errorf("invalid line %d, missing '=': %q", lineNo+1, line)
+		}
+		key := strings.TrimSpace(line[:eq])
+		value := strings.TrimSpace(line[eq+1:])
+		f.Add(currentGroup, key, value)
+	}
+
+	return f, nil
+}
+
+// NewUnitFromString is a convenience wrapper used by tests that need an
+// in-memory UnitFile without touching disk.
+func NewUnitFromString(data string) (*UnitFile, error) {
+	return ParseUnitFileString(data)
+}
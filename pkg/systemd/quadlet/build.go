@@ -0,0 +1,141 @@
+package quadlet
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/containers/podman/v4/pkg/systemd/parser"
+)
+
+const (
+	// BuildGroup holds the keys of a .build file.
+	BuildGroup = "Build"
+
+	// KeyFile is the path to the Containerfile/Dockerfile to build,
+	// relative to the build context; it maps to podman build's
+	// --file.
+	KeyFile = "File"
+	// KeySetWorkingDirectory is the build context directory; it is
+	// passed as-is as podman build's positional context argument
+	// (default "."). A relative path is not resolved against the
+	// Quadlet file's directory.
+	KeySetWorkingDirectory = "SetWorkingDirectory"
+	KeyImageTag            = "ImageTag"
+	KeyBuildArg            = "BuildArg"
+	KeyTarget              = "Target"
+	KeySecret              = "Secret"
+	KeyPull                = "Pull"
+	KeyPlatform            = "Platform"
+)
+
+var buildSupportedKeys = map[string]bool{
+	KeyFile:                true,
+	KeySetWorkingDirectory: true,
+	KeyImageTag:            true,
+	KeyLabel:               true,
+	KeyBuildArg:            true,
+	KeyTarget:              true,
+	KeySecret:              true,
+	KeyPull:                true,
+	KeyPlatform:            true,
+	KeyPodmanArgs:          true,
+}
+
+// buildImageTag returns the image tag a .build Quadlet file produces:
+// the value of ImageTag=, or "systemd-<name>" if the unit doesn't set
+// one, the same "systemd-" convention ConvertNetwork uses for the
+// networks it names.
+func buildImageTag(build *parser.UnitFile) string {
+	if tag, ok := build.LookupLast(BuildGroup, KeyImageTag); ok && tag != "" {
+		return tag
+	}
+	name := strings.TrimSuffix(build.Filename(), ".build")
+	return "systemd-" + name
+}
+
+// ConvertBuild converts a parsed .build Quadlet file into a Type=oneshot
+// systemd service that builds the image via "podman build" and exits;
+// RemainAfterExit keeps it considered "active" (and thus satisfying
+// Requires=/After= from a consuming .container) once the build
+// completes.
+func ConvertBuild(build *parser.UnitFile) (*parser.UnitFile, error) {
+	service := build.Clone(false)
+	service.Path = ServiceNameFor(build.Filename())
+
+	if err := checkForUnknownKeys(build, BuildGroup, buildSupportedKeys); err != nil {
+		return nil, err
+	}
+
+	baseName := strings.TrimSuffix(build.Filename(), ".build")
+	tag := buildImageTag(build)
+
+	service.Add(UnitGroup, "Description", fmt.Sprintf("Podman build %s", baseName))
+	service.Add(UnitGroup, "SourcePath", build.Path)
+
+	service.Add(ServiceGroup, "Type", "oneshot")
+	service.Add(ServiceGroup, "RemainAfterExit", "yes")
+	service.Add(ServiceGroup, "SyslogIdentifier", "%N")
+
+	execArgs := []string{"podman", "build", "--tag", tag}
+
+	for _, label := range build.LookupAll(BuildGroup, KeyLabel) {
+		execArgs = append(execArgs, "--label", label)
+	}
+	for _, arg := range build.LookupAll(BuildGroup, KeyBuildArg) {
+		execArgs = append(execArgs, "--build-arg", arg)
+	}
+	if target, ok := build.LookupLast(BuildGroup, KeyTarget); ok && target != "" {
+		execArgs = append(execArgs, "--target", target)
+	}
+	for _, secret := range build.LookupAll(BuildGroup, KeySecret) {
+		execArgs = append(execArgs, "--secret", secret)
+	}
+	if pull, ok := build.LookupLast(BuildGroup, KeyPull); ok && pull != "" {
+		execArgs = append(execArgs, "--pull", pull)
+	}
+	if platform, ok := build.LookupLast(BuildGroup, KeyPlatform); ok && platform != "" {
+		execArgs = append(execArgs, "--platform", platform)
+	}
+	if file, ok := build.LookupLast(BuildGroup, KeyFile); ok && file != "" {
+		execArgs = append(execArgs, "--file", file)
+	}
+	execArgs = append(execArgs, handlePodmanArgs(build, BuildGroup)...)
+
+	context, ok := build.LookupLast(BuildGroup, KeySetWorkingDirectory)
+	if !ok || context == "" {
+		context = "."
+	}
+	execArgs = append(execArgs, context)
+
+	service.Add(ServiceGroup, "ExecStart", quoteArgs(execArgs))
+
+	return service, nil
+}
+
+// resolveBuildArgs returns the image reference a container
+// should use when Image=foo.build, translating the reference into the
+// tag that .build file's generated service produces.
+func resolveBuildArgs(container *parser.UnitFile, units map[string]*parser.UnitFile) (string, bool) {
+	image, ok := container.LookupLast(ContainerGroup, KeyImage)
+	if !ok || !strings.HasSuffix(image, ".build") {
+		return "", false
+	}
+	if build, ok := units[image]; ok {
+		return buildImageTag(build), true
+	}
+	name := strings.TrimSuffix(image, ".build")
+	return "systemd-" + name, true
+}
+
+// handleBuildDependency adds Requires=/After= on the generated build
+// service for an Image=foo.build reference, so the image is built
+// before the container that runs it is started.
+func handleBuildDependency(container, service *parser.UnitFile) {
+	image, ok := container.LookupLast(ContainerGroup, KeyImage)
+	if !ok || !strings.HasSuffix(image, ".build") {
+		return
+	}
+	buildServiceName := ServiceNameFor(image)
+	service.Add(UnitGroup, "Requires", buildServiceName)
+	service.Add(UnitGroup, "After", buildServiceName)
+}
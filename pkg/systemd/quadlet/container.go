@@ -0,0 +1,121 @@
+package quadlet
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/containers/podman/v4/pkg/systemd/parser"
+)
+
+var containerSupportedKeys = map[string]bool{
+	KeyImage:       true,
+	KeyExec:        true,
+	KeyEnvironment: true,
+	KeyLabel:       true,
+	KeyPodmanArgs:  true,
+	KeyPod:         true,
+	KeyNetwork:     true,
+}
+
+// ConvertContainer converts a parsed .container Quadlet file into the
+// systemd service that runs it via "podman run". units contains every
+// other Quadlet file discovered alongside it, used to resolve an
+// Image=foo.build reference to the tag that .build file declares.
+func ConvertContainer(container *parser.UnitFile, units map[string]*parser.UnitFile) (*parser.UnitFile, error) {
+	service := container.Clone(false)
+	service.Path = ServiceNameFor(container.Filename())
+
+	if err := checkForUnknownKeys(container, ContainerGroup, containerSupportedKeys); err != nil {
+		return nil, err
+	}
+
+	containerName := strings.TrimSuffix(container.Filename(), ".container")
+
+	image, ok := container.LookupLast(ContainerGroup, KeyImage)
+	if !ok || image == "" {
+		return nil, fmt.Errorf("no Image key specified")
+	}
+	if buildTag, ok := resolveBuildArgs(container, units); ok {
+		image = buildTag
+	}
+
+	service.Add(UnitGroup, "Description", fmt.Sprintf("Podman container %s", containerName))
+	service.Add(UnitGroup, "SourcePath", container.Path)
+	service.Add(UnitGroup, "RequiresMountsFor", "%t/containers")
+
+	service.Add(ServiceGroup, "Type", "notify")
+	service.Add(ServiceGroup, "NotifyAccess", "all")
+	service.Add(ServiceGroup, "SyslogIdentifier", "%N")
+
+	podArgs, err := resolvePodArgs(container)
+	if err != nil {
+		return nil, err
+	}
+
+	execArgs := []string{"podman", "run", "--name", containerName, "--cidfile=%t/%N.cid", "--replace", "--rm", "--sdnotify=conmon", "-d"}
+	for _, env := range container.LookupAll(ContainerGroup, KeyEnvironment) {
+		execArgs = append(execArgs, "--env", env)
+	}
+	for _, label := range container.LookupAll(ContainerGroup, KeyLabel) {
+		execArgs = append(execArgs, "--label", label)
+	}
+	execArgs = append(execArgs, podArgs...)
+	execArgs = append(execArgs, resolveNetworkArgs(container)...)
+	execArgs = append(execArgs, handlePodmanArgs(container, ContainerGroup)...)
+	execArgs = append(execArgs, image)
+
+	if exec, ok := container.LookupLast(ContainerGroup, KeyExec); ok && exec != "" {
+		args, err := splitArgs(exec)
+		if err != nil {
+			return nil, fmt.Errorf("invalid Exec value: %w", err)
+		}
+		execArgs = append(execArgs, args...)
+	}
+
+	service.Add(ServiceGroup, "ExecStart", quoteArgs(execArgs))
+	service.Add(ServiceGroup, "ExecStop", quoteArgs([]string{"podman", "stop", "--ignore", "--cidfile=%t/%N.cid"}))
+	service.Add(ServiceGroup, "ExecStopPost", quoteArgs([]string{"podman", "rm", "-f", "--ignore", "--cidfile=%t/%N.cid"}))
+
+	handlePodDependency(container, service)
+	handleNetworkDependency(container, service)
+	handleBuildDependency(container, service)
+
+	handleDefaultDependencies(service, container, UnitGroup)
+
+	return service, nil
+}
+
+// resolvePodArgs returns the "--pod" "<name>" arguments (if any) to splice
+// into the container's ExecStart, without yet touching [Unit]
+// dependencies — those are added separately by handlePodDependency so
+// that a failure to resolve the pod doesn't leave half-built unit
+// dependencies behind.
+func resolvePodArgs(container *parser.UnitFile) ([]string, error) {
+	podFile, ok := container.LookupLast(ContainerGroup, KeyPod)
+	if !ok || podFile == "" {
+		return nil, nil
+	}
+	if !strings.HasSuffix(podFile, ".pod") {
+		return nil, fmt.Errorf("%s value %q must reference a .pod file", KeyPod, podFile)
+	}
+	podName := strings.TrimSuffix(podFile, ".pod")
+	return []string{"--pod", podName}, nil
+}
+
+// handlePodDependency binds the container's generated service to the
+// pod's generated service: BindsTo=/After= so the pod is started before
+// its containers and a container is stopped when the pod service stops,
+// and PartOf= so that a restart or stop of the pod service propagates
+// to every container in it (e.g. `systemctl restart foo-pod.service`).
+// The pod itself stops once all of its containers have exited because
+// ConvertPod creates it with --exit-policy=stop.
+func handlePodDependency(container, service *parser.UnitFile) {
+	podFile, ok := container.LookupLast(ContainerGroup, KeyPod)
+	if !ok || podFile == "" {
+		return
+	}
+	podServiceName := ServiceNameFor(podFile)
+	service.Add(UnitGroup, "BindsTo", podServiceName)
+	service.Add(UnitGroup, "After", podServiceName)
+	service.Add(UnitGroup, "PartOf", podServiceName)
+}
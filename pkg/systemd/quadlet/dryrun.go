@@ -0,0 +1,144 @@
+package quadlet
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/containers/podman/v4/pkg/systemd/parser"
+)
+
+// DryRunUnit describes a single Quadlet source file as it would be
+// converted, without writing anything to disk. It mirrors the pieces
+// of the generated service that are otherwise only observable by
+// inspecting the written unit file: the resolved command lines,
+// cross-unit dependencies, and anything that went wrong.
+type DryRunUnit struct {
+	// Name is the Quadlet source file name, e.g. "foo.container".
+	Name string `json:"name"`
+	// ServiceName is the systemd unit the source generates, e.g.
+	// "foo.service" or "foo-volume.service".
+	ServiceName string `json:"serviceName"`
+	// ExecStart is the shell-split argv that will run as the
+	// service's ExecStart=, if any.
+	ExecStart []string `json:"execStart,omitempty"`
+	// ExecStop is the shell-split argv that will run as the
+	// service's ExecStop=, if any.
+	ExecStop []string `json:"execStop,omitempty"`
+	// Dependencies lists every unit named in the generated service's
+	// After=/Requires=/BindsTo= (including targets such as
+	// network-online.target), deduplicated.
+	Dependencies []string `json:"dependencies,omitempty"`
+	// Warnings lists non-fatal issues noticed while converting the
+	// unit, e.g. a Pod=/Network=/Image= reference to a unit that
+	// wasn't found among the files scanned alongside it. Unlike
+	// Errors, these don't stop the unit from converting.
+	Warnings []string `json:"warnings,omitempty"`
+	// Errors holds the conversion error, if the unit failed to
+	// convert at all; ServiceName and the other fields are empty in
+	// that case.
+	Errors []string `json:"errors,omitempty"`
+}
+
+// DryRunResult is the top level JSON document `quadlet -dryrun
+// -format=json` writes to stdout.
+type DryRunResult struct {
+	Units []DryRunUnit `json:"units"`
+}
+
+// DryRun converts every Quadlet file in sourceDir the same way
+// GenerateUnits does, but instead of writing out unit files it returns
+// a description of what each unit would generate. It never returns an
+// error for a single unit failing to convert — that failure is
+// recorded in the unit's Errors field instead — so that the JSON output
+// always covers every source file.
+func DryRun(sourceDir string) (*DryRunResult, error) {
+	units, err := LoadUnitFilesFromDir(sourceDir)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &DryRunResult{}
+	for name, unit := range units {
+		du := DryRunUnit{Name: name}
+
+		service, convertErr := convert(name, unit, units)
+		if convertErr != nil {
+			du.Errors = append(du.Errors, convertErr.Error())
+			result.Units = append(result.Units, du)
+			continue
+		}
+
+		du.ServiceName = filepath.Base(service.Path)
+		if args, ok := service.LookupLastArgs(ServiceGroup, "ExecStart"); ok {
+			du.ExecStart = args
+		}
+		if args, ok := service.LookupLastArgs(ServiceGroup, "ExecStop"); ok {
+			du.ExecStop = args
+		}
+		du.Dependencies = dedupDependencies(service)
+		du.Warnings = unresolvedReferenceWarnings(unit, units)
+
+		result.Units = append(result.Units, du)
+	}
+	return result, nil
+}
+
+// unresolvedReferenceWarnings flags a .container's Pod=, Network= and
+// Image=foo.build references that don't point at a unit found in the
+// same scanned directory. This is exactly the kind of mistake dry-run
+// exists to catch: the reference still produces a valid-looking
+// ExecStart (Quadlet doesn't need the referenced file to build the
+// argv), so it stays a warning rather than a conversion error, but it
+// almost certainly means the generated service will fail to start.
+func unresolvedReferenceWarnings(unit *parser.UnitFile, units map[string]*parser.UnitFile) []string {
+	if filepath.Ext(unit.Filename()) != ".container" {
+		return nil
+	}
+
+	var warnings []string
+	checkRef := func(key, value string) {
+		if value == "" {
+			return
+		}
+		if _, ok := units[value]; !ok {
+			warnings = append(warnings, fmt.Sprintf("%s=%s: no such unit found alongside this file", key, value))
+		}
+	}
+
+	if podFile, ok := unit.LookupLast(ContainerGroup, KeyPod); ok {
+		checkRef(KeyPod, podFile)
+	}
+	for _, value := range unit.LookupAll(ContainerGroup, KeyNetwork) {
+		if strings.HasSuffix(value, ".network") {
+			checkRef(KeyNetwork, value)
+		}
+	}
+	if image, ok := unit.LookupLast(ContainerGroup, KeyImage); ok && strings.HasSuffix(image, ".build") {
+		checkRef(KeyImage, image)
+	}
+
+	return warnings
+}
+
+func dedupDependencies(service *parser.UnitFile) []string {
+	seen := make(map[string]bool)
+	var deps []string
+	for _, key := range []string{"After", "Requires", "BindsTo"} {
+		for _, value := range service.LookupAll(UnitGroup, key) {
+			if seen[value] {
+				continue
+			}
+			seen[value] = true
+			deps = append(deps, value)
+		}
+	}
+	return deps
+}
+
+// ToJSON renders the dry-run result as indented JSON, the format
+// `quadlet -dryrun -format=json` writes to stdout.
+func (r *DryRunResult) ToJSON() ([]byte, error) {
+	return json.MarshalIndent(r, "", "  ")
+}
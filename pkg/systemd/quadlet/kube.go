@@ -0,0 +1,51 @@
+package quadlet
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/containers/podman/v4/pkg/systemd/parser"
+)
+
+var kubeSupportedKeys = map[string]bool{
+	KeyYaml:       true,
+	KeyPodmanArgs: true,
+}
+
+// ConvertKube converts a parsed .kube Quadlet file into the systemd
+// service that applies its Yaml= manifest via "podman kube play" and
+// tears it down with "podman kube down" on stop.
+func ConvertKube(kube *parser.UnitFile) (*parser.UnitFile, error) {
+	service := kube.Clone(false)
+	service.Path = ServiceNameFor(kube.Filename())
+
+	if err := checkForUnknownKeys(kube, KubeGroup, kubeSupportedKeys); err != nil {
+		return nil, err
+	}
+
+	yamlPath, ok := kube.LookupLast(KubeGroup, KeyYaml)
+	if !ok || yamlPath == "" {
+		return nil, fmt.Errorf("no Yaml key specified")
+	}
+
+	baseName := strings.TrimSuffix(kube.Filename(), ".kube")
+
+	service.Add(UnitGroup, "Description", fmt.Sprintf("Podman kube %s", baseName))
+	service.Add(UnitGroup, "SourcePath", kube.Path)
+	service.Add(UnitGroup, "RequiresMountsFor", "%t/containers")
+
+	service.Add(ServiceGroup, "Type", "oneshot")
+	service.Add(ServiceGroup, "RemainAfterExit", "yes")
+	service.Add(ServiceGroup, "SyslogIdentifier", "%N")
+
+	execArgs := []string{"podman", "kube", "play", "--replace"}
+	execArgs = append(execArgs, handlePodmanArgs(kube, KubeGroup)...)
+	execArgs = append(execArgs, yamlPath)
+
+	service.Add(ServiceGroup, "ExecStart", quoteArgs(execArgs))
+	service.Add(ServiceGroup, "ExecStop", quoteArgs([]string{"podman", "kube", "down", yamlPath}))
+
+	handleDefaultDependencies(service, kube, UnitGroup)
+
+	return service, nil
+}
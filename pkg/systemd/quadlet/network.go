@@ -0,0 +1,132 @@
+package quadlet
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/containers/podman/v4/pkg/systemd/parser"
+)
+
+const (
+	// NetworkGroup holds the keys of a .network file.
+	NetworkGroup = "Network"
+
+	KeyDriver     = "Driver"
+	KeySubnet     = "Subnet"
+	KeyGateway    = "Gateway"
+	KeyIPRange    = "IPRange"
+	KeyInternal   = "Internal"
+	KeyIPv6       = "IPv6"
+	KeyDisableDNS = "DisableDNS"
+	KeyOptions    = "Options"
+	KeyNetwork    = "Network"
+)
+
+var networkSupportedKeys = map[string]bool{
+	KeyDriver:     true,
+	KeySubnet:     true,
+	KeyGateway:    true,
+	KeyIPRange:    true,
+	KeyLabel:      true,
+	KeyInternal:   true,
+	KeyIPv6:       true,
+	KeyDisableDNS: true,
+	KeyOptions:    true,
+	KeyPodmanArgs: true,
+}
+
+// networkName returns the podman network name a .network Quadlet file
+// generates. It is prefixed with "systemd-" so that networks created by
+// Quadlet are easy to tell apart from ones a user created by hand.
+// (Volumes are not prefixed: ConvertVolume uses the bare base name.)
+func networkName(networkFile string) string {
+	base := strings.TrimSuffix(networkFile, ".network")
+	return "systemd-" + base
+}
+
+// ConvertNetwork converts a parsed .network Quadlet file into the
+// systemd service that creates the podman network idempotently via
+// "podman network create" and tears it down with "podman network rm".
+func ConvertNetwork(network *parser.UnitFile) (*parser.UnitFile, error) {
+	service := network.Clone(false)
+	service.Path = ServiceNameFor(network.Filename())
+
+	if err := checkForUnknownKeys(network, NetworkGroup, networkSupportedKeys); err != nil {
+		return nil, err
+	}
+
+	name := networkName(network.Filename())
+
+	service.Add(UnitGroup, "Description", fmt.Sprintf("Podman network %s", name))
+	service.Add(UnitGroup, "SourcePath", network.Path)
+
+	service.Add(ServiceGroup, "Type", "oneshot")
+	service.Add(ServiceGroup, "RemainAfterExit", "yes")
+	service.Add(ServiceGroup, "SyslogIdentifier", "%N")
+
+	execArgs := []string{"podman", "network", "create", "--ignore"}
+
+	if driver, ok := network.LookupLast(NetworkGroup, KeyDriver); ok && driver != "" {
+		execArgs = append(execArgs, "--driver", driver)
+	}
+	for _, subnet := range network.LookupAll(NetworkGroup, KeySubnet) {
+		execArgs = append(execArgs, "--subnet", subnet)
+	}
+	for _, gateway := range network.LookupAll(NetworkGroup, KeyGateway) {
+		execArgs = append(execArgs, "--gateway", gateway)
+	}
+	for _, ipRange := range network.LookupAll(NetworkGroup, KeyIPRange) {
+		execArgs = append(execArgs, "--ip-range", ipRange)
+	}
+	for _, label := range network.LookupAll(NetworkGroup, KeyLabel) {
+		execArgs = append(execArgs, "--label", label)
+	}
+	if network.LookupBoolean(NetworkGroup, KeyInternal, false) {
+		execArgs = append(execArgs, "--internal")
+	}
+	if network.LookupBoolean(NetworkGroup, KeyIPv6, false) {
+		execArgs = append(execArgs, "--ipv6")
+	}
+	if network.LookupBoolean(NetworkGroup, KeyDisableDNS, false) {
+		execArgs = append(execArgs, "--disable-dns")
+	}
+	for _, option := range network.LookupAll(NetworkGroup, KeyOptions) {
+		execArgs = append(execArgs, "--opt", option)
+	}
+	execArgs = append(execArgs, handlePodmanArgs(network, NetworkGroup)...)
+	execArgs = append(execArgs, name)
+
+	service.Add(ServiceGroup, "ExecStart", quoteArgs(execArgs))
+	service.Add(ServiceGroup, "ExecStop", quoteArgs([]string{"podman", "network", "rm", "--ignore", name}))
+
+	return service, nil
+}
+
+// resolveNetworkArgs returns the "--network" "<name>" arguments (if any) to
+// splice into the container's ExecStart, translating a Network=foo.network
+// reference into the systemd-prefixed podman network name.
+func resolveNetworkArgs(container *parser.UnitFile) []string {
+	var args []string
+	for _, value := range container.LookupAll(ContainerGroup, KeyNetwork) {
+		if strings.HasSuffix(value, ".network") {
+			args = append(args, "--network", networkName(value))
+			continue
+		}
+		args = append(args, "--network", value)
+	}
+	return args
+}
+
+// handleNetworkDependency adds Requires=/After= on the generated
+// network service for every Network=foo.network reference, so the
+// network exists before the container that uses it is started.
+func handleNetworkDependency(container, service *parser.UnitFile) {
+	for _, value := range container.LookupAll(ContainerGroup, KeyNetwork) {
+		if !strings.HasSuffix(value, ".network") {
+			continue
+		}
+		networkServiceName := ServiceNameFor(value)
+		service.Add(UnitGroup, "Requires", networkServiceName)
+		service.Add(UnitGroup, "After", networkServiceName)
+	}
+}
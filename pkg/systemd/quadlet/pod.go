@@ -0,0 +1,55 @@
+package quadlet
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/containers/podman/v4/pkg/systemd/parser"
+)
+
+var podSupportedKeys = map[string]bool{
+	KeyLabel:      true,
+	KeyPodmanArgs: true,
+}
+
+// ConvertPod converts a parsed .pod Quadlet file into the systemd
+// service that creates, starts and stops the pod via podman. The
+// generated unit runs "podman pod create" in ExecStartPre, "podman pod
+// start" as ExecStart and "podman pod stop"/"podman pod rm" in
+// ExecStop/ExecStopPost. The service is named "<name>-pod.service"; the
+// infra container podman creates for the pod is not itself exposed as a
+// separate unit.
+func ConvertPod(pod *parser.UnitFile) (*parser.UnitFile, error) {
+	service := pod.Clone(false)
+	service.Path = ServiceNameFor(pod.Filename())
+
+	if err := checkForUnknownKeys(pod, PodGroup, podSupportedKeys); err != nil {
+		return nil, err
+	}
+
+	podName := strings.TrimSuffix(pod.Filename(), ".pod")
+	podIDFile := fmt.Sprintf("%%t/pod-%s.pod-id", podName)
+
+	service.Add(UnitGroup, "Description", fmt.Sprintf("Podman pod %s", podName))
+	service.Add(UnitGroup, "SourcePath", pod.Path)
+	service.Add(UnitGroup, "RequiresMountsFor", "%t/containers")
+
+	service.Add(ServiceGroup, "Type", "oneshot")
+	service.Add(ServiceGroup, "RemainAfterExit", "yes")
+	service.Add(ServiceGroup, "SyslogIdentifier", "%N")
+
+	createArgs := []string{"podman", "pod", "create", "--name", podName, "--pod-id-file=" + podIDFile, "--exit-policy=stop"}
+	for _, label := range pod.LookupAll(PodGroup, KeyLabel) {
+		createArgs = append(createArgs, "--label", label)
+	}
+	createArgs = append(createArgs, handlePodmanArgs(pod, PodGroup)...)
+
+	service.Add(ServiceGroup, "ExecStartPre", quoteArgs(createArgs))
+	service.Add(ServiceGroup, "ExecStart", quoteArgs([]string{"podman", "pod", "start", "--pod-id-file=" + podIDFile}))
+	service.Add(ServiceGroup, "ExecStop", quoteArgs([]string{"podman", "pod", "stop", "--ignore", "--pod-id-file=" + podIDFile}))
+	service.Add(ServiceGroup, "ExecStopPost", quoteArgs([]string{"podman", "pod", "rm", "-f", "--ignore", "--pod-id-file=" + podIDFile}))
+
+	handleDefaultDependencies(service, pod, UnitGroup)
+
+	return service, nil
+}
@@ -0,0 +1,211 @@
+// Package quadlet converts "Quadlet" unit files (.container, .volume,
+// .kube, .pod, .network, .build) into systemd service units that run
+// the equivalent podman command. It is the library used by the
+// `quadlet` systemd generator binary; see cmd/quadlet.
+package quadlet
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/containers/podman/v4/pkg/systemd/parser"
+)
+
+const (
+	// UnitGroup is the standard systemd [Unit] group.
+	UnitGroup = "Unit"
+	// ServiceGroup is the standard systemd [Service] group.
+	ServiceGroup = "Service"
+
+	// ContainerGroup holds the keys of a .container file.
+	ContainerGroup = "Container"
+	// VolumeGroup holds the keys of a .volume file.
+	VolumeGroup = "Volume"
+	// KubeGroup holds the keys of a .kube file.
+	KubeGroup = "Kube"
+	// PodGroup holds the keys of a .pod file.
+	PodGroup = "Pod"
+
+	KeyImage       = "Image"
+	KeyExec        = "Exec"
+	KeyEnvironment = "Environment"
+	KeyLabel       = "Label"
+	KeyPodmanArgs  = "PodmanArgs"
+	KeyPod         = "Pod"
+	KeyYaml        = "Yaml"
+)
+
+// serviceSuffixes maps a Quadlet file extension to the suffix inserted
+// before ".service" in the generated unit name, e.g. "foo.volume" ->
+// "foo-volume.service". An empty suffix means the generated name
+// matches the base name exactly, which is how .container and .kube
+// have always behaved.
+var serviceSuffixes = map[string]string{
+	".container": "",
+	".volume":    "-volume",
+	".kube":      "",
+	".pod":       "-pod",
+	".network":   "-network",
+	".build":     "-build",
+}
+
+// replaceExtension swaps a Quadlet file's extension for the generated
+// service name, inserting that kind's suffix before ".service". This is
+// the single place that encodes the naming convention so that
+// container/pod/volume cross-references all agree on what a unit will
+// be called.
+func replaceExtension(name, suffix string) string {
+	extension := filepath.Ext(name)
+	base := strings.TrimSuffix(name, extension)
+	return base + suffix + ".service"
+}
+
+// ServiceNameFor returns the systemd unit name generated for a Quadlet
+// source file, e.g. "foo.volume" -> "foo-volume.service".
+func ServiceNameFor(sourceFile string) string {
+	ext := filepath.Ext(sourceFile)
+	return replaceExtension(sourceFile, serviceSuffixes[ext])
+}
+
+func checkForUnknownKeys(unit *parser.UnitFile, group string, supportedKeys map[string]bool) error {
+	for _, key := range unit.ListKeys(group) {
+		if !supportedKeys[key] {
+			return fmt.Errorf("unsupported key '%s' in group '%s' in %s", key, group, unit.Filename())
+		}
+	}
+	return nil
+}
+
+// handleDefaultDependencies adds the After=/Wants= pair that every
+// generated Quadlet service sets on network-online.target, unless the
+// source unit already customized its [Unit] dependencies.
+func handleDefaultDependencies(service, source *parser.UnitFile, group string) {
+	if len(source.LookupAll(group, "After")) == 0 {
+		service.Add(group, "After", "network-online.target")
+	}
+	if len(source.LookupAll(group, "Wants")) == 0 {
+		service.Add(group, "Wants", "network-online.target")
+	}
+}
+
+// handlePodmanArgs copies any PodmanArgs= values from the Quadlet
+// source group, already shell-split, so they can be appended verbatim
+// to the generated podman command line.
+func handlePodmanArgs(unit *parser.UnitFile, group string) []string {
+	var out []string
+	for _, value := range unit.LookupAll(group, KeyPodmanArgs) {
+		args, err := splitArgs(value)
+		if err != nil {
+			continue
+		}
+		out = append(out, args...)
+	}
+	return out
+}
+
+func quoteArgs(args []string) string {
+	quoted := make([]string, 0, len(args))
+	for _, a := range args {
+		if strings.ContainsAny(a, " \t\"'$") {
+			a = "\"" + strings.ReplaceAll(a, "\"", "\\\"") + "\""
+		}
+		quoted = append(quoted, a)
+	}
+	return strings.Join(quoted, " ")
+}
+
+func splitArgs(value string) ([]string, error) {
+	unit, err := parser.NewUnitFromString("[X]\nY=" + value + "\n")
+	if err != nil {
+		return nil, err
+	}
+	args, ok := unit.LookupLastArgs("X", "Y")
+	if !ok {
+		return nil, fmt.Errorf("could not split args: %q", value)
+	}
+	return args, nil
+}
+
+// LoadUnitFilesFromDir reads every Quadlet file with a recognized
+// extension out of sourceDir, keyed by file name, for the generator to
+// resolve cross-unit references (Pod=, Network=, Image=foo.build) by
+// name before conversion.
+func LoadUnitFilesFromDir(sourceDir string) (map[string]*parser.UnitFile, error) {
+	entries, err := os.ReadDir(sourceDir)
+	if err != nil {
+		return nil, err
+	}
+
+	units := make(map[string]*parser.UnitFile)
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		if _, ok := serviceSuffixes[filepath.Ext(entry.Name())]; !ok {
+			continue
+		}
+		unit, err := parser.ParseUnitFile(filepath.Join(sourceDir, entry.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %w", entry.Name(), err)
+		}
+		units[entry.Name()] = unit
+	}
+	return units, nil
+}
+
+// GenerateUnits converts every Quadlet file discovered in sourceDir into
+// its generated systemd service and writes the result to outputDir. It
+// returns one error per unit that failed to convert; an empty slice
+// means every unit converted successfully.
+func GenerateUnits(sourceDir, outputDir string) []error {
+	units, err := LoadUnitFilesFromDir(sourceDir)
+	if err != nil {
+		return []error{err}
+	}
+
+	var errs []error
+	for name, unit := range units {
+		service, err := convert(name, unit, units)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", name, err))
+			continue
+		}
+		if service == nil {
+			// Every supported kind, including resource-only units
+			// (volume/network/build/pod), produces a service, and
+			// convert rejects unknown extensions with an error, so
+			// this is only a guard.
+			continue
+		}
+		out, err := service.ToString()
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", name, err))
+			continue
+		}
+		if err := os.WriteFile(filepath.Join(outputDir, filepath.Base(service.Path)), []byte(out), 0644); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", name, err))
+		}
+	}
+	return errs
+}
+
+func convert(name string, unit *parser.UnitFile, units map[string]*parser.UnitFile) (*parser.UnitFile, error) {
+	switch filepath.Ext(name) {
+	case ".container":
+		return ConvertContainer(unit, units)
+	case ".volume":
+		return ConvertVolume(unit)
+	case ".kube":
+		return ConvertKube(unit)
+	case ".pod":
+		return ConvertPod(unit)
+	case ".network":
+		return ConvertNetwork(unit)
+	case ".build":
+		return ConvertBuild(unit)
+	default:
+		return nil, fmt.Errorf("unsupported file type %q", name)
+	}
+}
@@ -0,0 +1,42 @@
+package quadlet
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/containers/podman/v4/pkg/systemd/parser"
+)
+
+var volumeSupportedKeys = map[string]bool{
+	KeyLabel: true,
+}
+
+// ConvertVolume converts a parsed .volume Quadlet file into the systemd
+// service that creates the named podman volume idempotently.
+func ConvertVolume(volume *parser.UnitFile) (*parser.UnitFile, error) {
+	service := volume.Clone(false)
+	service.Path = ServiceNameFor(volume.Filename())
+
+	if err := checkForUnknownKeys(volume, VolumeGroup, volumeSupportedKeys); err != nil {
+		return nil, err
+	}
+
+	volumeName := strings.TrimSuffix(volume.Filename(), ".volume")
+
+	service.Add(UnitGroup, "Description", fmt.Sprintf("Podman volume %s", volumeName))
+	service.Add(UnitGroup, "SourcePath", volume.Path)
+
+	service.Add(ServiceGroup, "Type", "oneshot")
+	service.Add(ServiceGroup, "RemainAfterExit", "yes")
+	service.Add(ServiceGroup, "SyslogIdentifier", "%N")
+
+	execArgs := []string{"podman", "volume", "create", "--ignore"}
+	for _, label := range volume.LookupAll(VolumeGroup, KeyLabel) {
+		execArgs = append(execArgs, "--label", label)
+	}
+	execArgs = append(execArgs, volumeName)
+
+	service.Add(ServiceGroup, "ExecStart", quoteArgs(execArgs))
+
+	return service, nil
+}
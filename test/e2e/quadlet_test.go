@@ -1,9 +1,11 @@
 package integration
 
 import (
+	"encoding/json"
 	"fmt"
 	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
 
 	"github.com/containers/podman/v4/pkg/systemd/parser"
@@ -32,6 +34,15 @@ func loadQuadletTestcase(path string) *quadletTestcase {
 	if ext == ".volume" {
 		service += "-volume"
 	}
+	if ext == ".pod" {
+		service += "-pod"
+	}
+	if ext == ".network" {
+		service += "-network"
+	}
+	if ext == ".build" {
+		service += "-build"
+	}
 	service += ".service"
 
 	checks := make([][]string, 0)
@@ -139,6 +150,163 @@ func (t *quadletTestcase) assertStopPodmanFinalArgs(args []string, unit *parser.
 	return t.assertPodmanFinalArgs(args, unit, "ExecStop")
 }
 
+// assertJSONContains implements the "assert-json-contains <jsonpath>
+// <value>" op: it parses the `quadlet -dryrun -format=json` output
+// captured on session and evaluates a jsonpath-lite expression
+// against it, comparing the result against value. Only the subset of
+// JSONPath Quadlet's dry-run output actually needs is supported:
+// dotted field access, numeric array indexing (`execStart[2]`), and a
+// single `[?(@.field=="value")]` filter for picking a unit out of the
+// top-level "units" array by name.
+func (t *quadletTestcase) assertJSONContains(args []string, session *PodmanSessionIntegration) bool {
+	path := args[0]
+	expected := args[1]
+
+	var doc interface{}
+	err := json.Unmarshal([]byte(session.OutputToString()), &doc)
+	Expect(err).ToNot(HaveOccurred())
+
+	value, err := evalJSONPath(doc, path)
+	Expect(err).ToNot(HaveOccurred())
+
+	return fmt.Sprintf("%v", value) == expected
+}
+
+// evalJSONPath walks doc (as produced by encoding/json.Unmarshal into
+// interface{}) following the dotted/bracketed path, e.g.
+// "$.units[?(@.name==\"foo.container\")].execStart[2]".
+func evalJSONPath(doc interface{}, path string) (interface{}, error) {
+	path = strings.TrimPrefix(path, "$")
+	path = strings.TrimPrefix(path, ".")
+
+	current := doc
+	for _, token := range splitJSONPathTokens(path) {
+		name, brackets := splitJSONPathToken(token)
+		if name != "" {
+			m, ok := current.(map[string]interface{})
+			if !ok {
+				return nil, fmt.Errorf("jsonpath: %q is not an object", name)
+			}
+			current, ok = m[name]
+			if !ok {
+				return nil, fmt.Errorf("jsonpath: no such field %q", name)
+			}
+		}
+		for _, b := range brackets {
+			var err error
+			current, err = applyJSONPathBracket(current, b)
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+	return current, nil
+}
+
+// splitJSONPathTokens splits a path into its dot-separated segments,
+// ignoring dots that appear inside a [...] bracket (e.g. inside a
+// quoted "foo.service" filter value).
+func splitJSONPathTokens(path string) []string {
+	var tokens []string
+	var cur strings.Builder
+	depth := 0
+	for _, r := range path {
+		switch {
+		case r == '[':
+			depth++
+			cur.WriteRune(r)
+		case r == ']':
+			depth--
+			cur.WriteRune(r)
+		case r == '.' && depth == 0:
+			if cur.Len() > 0 {
+				tokens = append(tokens, cur.String())
+				cur.Reset()
+			}
+		default:
+			cur.WriteRune(r)
+		}
+	}
+	if cur.Len() > 0 {
+		tokens = append(tokens, cur.String())
+	}
+	return tokens
+}
+
+// splitJSONPathToken splits a single segment such as
+// `units[?(@.name=="foo.container")]` into its field name and the
+// (possibly chained) bracket expressions that follow it.
+func splitJSONPathToken(token string) (string, []string) {
+	idx := strings.Index(token, "[")
+	if idx < 0 {
+		return token, nil
+	}
+	name := token[:idx]
+	rest := token[idx:]
+
+	var brackets []string
+	for len(rest) > 0 && rest[0] == '[' {
+		depth := 0
+		end := -1
+		for i, r := range rest {
+			if r == '[' {
+				depth++
+			} else if r == ']' {
+				depth--
+				if depth == 0 {
+					end = i
+					break
+				}
+			}
+		}
+		if end < 0 {
+			break
+		}
+		brackets = append(brackets, rest[1:end])
+		rest = rest[end+1:]
+	}
+	return name, brackets
+}
+
+func applyJSONPathBracket(current interface{}, bracket string) (interface{}, error) {
+	bracket = strings.TrimSpace(bracket)
+
+	if strings.HasPrefix(bracket, "?(") && strings.HasSuffix(bracket, ")") {
+		expr := strings.TrimSuffix(strings.TrimPrefix(bracket, "?("), ")")
+		parts := strings.SplitN(expr, "==", 2)
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("jsonpath: unsupported filter %q", bracket)
+		}
+		field := strings.TrimPrefix(strings.TrimSpace(parts[0]), "@.")
+		want := strings.Trim(strings.TrimSpace(parts[1]), `"`)
+
+		arr, ok := current.([]interface{})
+		if !ok {
+			return nil, fmt.Errorf("jsonpath: filter applied to a non-array")
+		}
+		for _, elem := range arr {
+			m, ok := elem.(map[string]interface{})
+			if ok && fmt.Sprintf("%v", m[field]) == want {
+				return elem, nil
+			}
+		}
+		return nil, fmt.Errorf("jsonpath: no element matching %s", bracket)
+	}
+
+	idx, err := strconv.Atoi(bracket)
+	if err != nil {
+		return nil, fmt.Errorf("jsonpath: unsupported index %q", bracket)
+	}
+	arr, ok := current.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("jsonpath: index applied to a non-array")
+	}
+	if idx < 0 || idx >= len(arr) {
+		return nil, fmt.Errorf("jsonpath: index %d out of range", idx)
+	}
+	return arr[idx], nil
+}
+
 func (t *quadletTestcase) assertSymlink(args []string, unit *parser.UnitFile) bool {
 	symlink := args[0]
 	expectedTarget := args[1]
@@ -186,6 +354,8 @@ func (t *quadletTestcase) doAssert(check []string, unit *parser.UnitFile, sessio
 		ok = t.assertStopPodmanArgs(args, unit)
 	case "assert-podman-stop-final-args":
 		ok = t.assertStopPodmanFinalArgs(args, unit)
+	case "assert-json-contains":
+		ok = t.assertJSONContains(args, session)
 	default:
 		return fmt.Errorf("Unsupported assertion %s", op)
 	}
@@ -226,11 +396,40 @@ func (t *quadletTestcase) check(generateDir string, session *PodmanSessionIntegr
 	}
 
 	for _, check := range t.checks {
+		if check[0] == "assert-json-contains" {
+			continue
+		}
 		err := t.doAssert(check, unit, session)
 		Expect(err).ToNot(HaveOccurred())
 	}
 }
 
+// needsJSONCheck reports whether any of the testcase's checks require
+// the dry-run JSON output, so plain testcases don't pay for a second
+// quadlet invocation they have no use for.
+func (t *quadletTestcase) needsJSONCheck() bool {
+	for _, check := range t.checks {
+		if check[0] == "assert-json-contains" {
+			return true
+		}
+	}
+	return false
+}
+
+// checkJSON runs only the "assert-json-contains" checks against the
+// JSON emitted by a `quadlet -dryrun -format=json` session, letting
+// testcases assert on dry-run-only output (warnings, dependency
+// ordering) that never reaches the generated unit file.
+func (t *quadletTestcase) checkJSON(dryRunSession *PodmanSessionIntegration) {
+	for _, check := range t.checks {
+		if check[0] != "assert-json-contains" {
+			continue
+		}
+		err := t.doAssert(check, nil, dryRunSession)
+		Expect(err).ToNot(HaveOccurred())
+	}
+}
+
 var _ = Describe("quadlet system generator", func() {
 	var (
 		tempdir      string
@@ -284,6 +483,13 @@ var _ = Describe("quadlet system generator", func() {
 			}
 
 			testcase.check(generatedDir, session)
+
+			if testcase.needsJSONCheck() {
+				dryRunSession := podmanTest.Quadlet([]string{"-dryrun", "-format=json"}, quadletDir)
+				dryRunSession.WaitWithDefaultTimeout()
+				Expect(dryRunSession).Should(Exit(0))
+				testcase.checkJSON(dryRunSession)
+			}
 		},
 		Entry("Basic container", "basic.container"),
 		Entry("annotation.container", "annotation.container"),
@@ -322,6 +528,19 @@ var _ = Describe("quadlet system generator", func() {
 		Entry("uid.volume", "uid.volume"),
 
 		Entry("Basic kube", "basic.kube"),
+
+		Entry("basic.pod", "basic.pod"),
+		Entry("container-in-pod.container", "container-in-pod.container"),
+
+		Entry("basic.network", "basic.network"),
+		Entry("container-with-network.container", "container-with-network.container"),
+
+		Entry("dryrun.container", "dryrun.container"),
+		Entry("dryrun-warning.container", "dryrun-warning.container"),
+
+		Entry("basic.build", "basic.build"),
+		Entry("container-with-build.container", "container-with-build.container"),
+		Entry("workdir.build", "workdir.build"),
 	)
 
 })